@@ -5,11 +5,14 @@ package amcp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/textproto"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -42,7 +45,22 @@ type Client struct {
 	// underlying connection
 	conn net.Conn
 
+	// Timeout, if non-zero, bounds every call to Do that isn't made
+	// through DoContext with its own deadline.
 	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending []chan response
+	subs    map[*sub]struct{}
+	logger  Logger
+}
+
+// a single reply delivered from the reader goroutine to the Do call that
+// is waiting for it.
+type response struct {
+	code int
+	data interface{}
+	err  error
 }
 
 // Dial returns a new Client connected to an AMCP server at addr.
@@ -57,7 +75,14 @@ func Dial(addr string) (*Client, error) {
 
 func newClient(conn net.Conn, addr string) (*Client, error) {
 	text := textproto.NewConn(conn)
-	return &Client{text: text, conn: conn, addr: addr}, nil
+	c := &Client{
+		text: text,
+		conn: conn,
+		addr: addr,
+		subs: make(map[*sub]struct{}),
+	}
+	go c.readLoop()
+	return c, nil
 }
 
 // Close closes the network connection.
@@ -68,40 +93,94 @@ func (c *Client) Close() error {
 // Do sends a command to the server and returns the reply.
 // If the server returned multiple lines of data, data is a []string, otherwise it's a string.
 func (c *Client) Do(cmd string, args ...interface{}) (code int, data interface{}, err error) {
-	id, err := c.send(cmd, args...)
+	return c.DoContext(context.Background(), cmd, args...)
+}
+
+// DoContext is like Do, but the command's write and the wait for its
+// reply are both bound by ctx. Timeout applies only when ctx carries no
+// deadline of its own. The deadline is set as the connection's write
+// deadline; the read deadline is left unchanged, since the connection's
+// single reader goroutine serves every call, and the wait for the reply
+// instead ends with ctx.Err() once ctx is done.
+func (c *Client) DoContext(ctx context.Context, cmd string, args ...interface{}) (code int, data interface{}, err error) {
+	if c.Timeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+			defer cancel()
+		}
+	}
+
+	ch, err := c.send(ctx, cmd, args...)
 	if err != nil {
-		return 0, "", err
+		return 0, nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		return resp.code, resp.data, resp.err
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
 	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
-	return c.receive()
 }
 
-// sends a command request to the server.
-func (c *Client) send(cmd string, args ...interface{}) (id uint, err error) {
-	id = c.text.Next()
+// send writes a command request to the server and registers a channel
+// to receive its reply in FIFO order once the reader goroutine parses
+// it off the wire.
+func (c *Client) send(ctx context.Context, cmd string, args ...interface{}) (<-chan response, error) {
+	id := c.text.Next()
 	c.text.StartRequest(id)
+	defer c.text.EndRequest(id)
 
 	var deadline time.Time
-	if c.Timeout > 0 {
-		deadline = time.Now().Add(c.Timeout)
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
 	}
 	c.conn.SetWriteDeadline(deadline)
 
-	_, err = c.text.W.WriteString(formatCmd(cmd, args...))
-	if err != nil {
-		return 0, err
+	// Register the reply channel before writing: StartRequest/EndRequest
+	// above already admit only one goroutine at a time into this
+	// section, so this append is always the last entry in c.pending at
+	// the moment it happens, and stays so until this function returns.
+	// That's what lets us undo it cleanly below.
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	c.pending = append(c.pending, ch)
+	c.mu.Unlock()
+
+	// A write or flush failure means the reply this channel was
+	// registered for will never arrive, and the connection can no
+	// longer be trusted to stay in sync. Pop the channel back off
+	// instead of leaving it abandoned in the queue, where deliver()
+	// would otherwise hand it some later call's reply and desync the
+	// FIFO for the rest of the connection's life.
+	if _, err := c.text.W.WriteString(formatCmd(cmd, args...)); err != nil {
+		c.unregister(ch)
+		c.conn.Close()
+		return nil, err
 	}
-	err = c.text.W.Flush()
-	if err != nil {
-		return 0, err
+	if err := c.text.W.Flush(); err != nil {
+		c.unregister(ch)
+		c.conn.Close()
+		return nil, err
 	}
-	c.text.EndRequest(id)
-	if err != nil {
-		return 0, err
+
+	if l := c.getLogger(); l != nil {
+		l.Log(DirSent, renderForLog(cmd, args))
 	}
 
-	return id, nil
+	return ch, nil
+}
+
+// unregister removes ch from c.pending if it is still there. It is only
+// ever called for the most recently registered channel, so it is always
+// the last entry, if present at all.
+func (c *Client) unregister(ch chan response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := len(c.pending); n > 0 && c.pending[n-1] == ch {
+		c.pending = c.pending[:n-1]
+	}
 }
 
 // preallocated new lines
@@ -148,59 +227,135 @@ func formatCmd(cmd string, args ...interface{}) string {
 	return b.String()
 }
 
-// reads a response from the server. Parses the return code and data.
-// data will be a []string if mutli-line data, otherwise string.
-func (c *Client) receive() (code int, data interface{}, err error) {
-	var deadline time.Time
-	if c.Timeout > 0 {
-		deadline = time.Now().Add(c.Timeout)
-	}
-	c.conn.SetReadDeadline(deadline)
+// readLoop runs for the lifetime of the connection on its own goroutine,
+// reading every line the server sends. Lines that parse as a return code
+// are matched, in order, to the oldest pending Do/DoContext call. A line
+// that doesn't parse as one is only treated as an unsolicited server
+// push if nothing is waiting on a reply right now; otherwise a real AMCP
+// server would never have sent it in place of the reply it owes, so it
+// fails the oldest pending call instead of being silently reinterpreted
+// as a push. This is what lets Do and Subscribe share a single
+// connection.
+func (c *Client) readLoop() {
 	r := bufio.NewReader(c.conn)
+	for {
+		b, err := r.ReadSlice('\n')
+		if err != nil {
+			c.closeAll(err)
+			return
+		}
+		line := string(b[:len(b)-2])
+		if l := c.getLogger(); l != nil {
+			l.Log(DirRecv, redactForLog(line))
+		}
 
-	b, err := r.ReadSlice('\n')
-	if err != nil {
-		return 0, "", err
-	}
-	line := string(b[:len(b)-2])
-	code, data, err = parseCodeLine(line)
-	if err != nil {
-		return 0, "", err
-	}
+		code, msg, cerr := parseCodeLine(line)
+		if cerr != nil {
+			if c.hasPending() {
+				c.deliver(response{err: fmt.Errorf("amcp: malformed reply: %q", line)})
+				continue
+			}
+			c.dispatchEvent(line)
+			continue
+		}
 
-	// read all lines if multi line response
-	if code == ReturnOkMulti || code == ReturnOkData {
-		v := []string{data.(string)}
+		var data interface{} = msg
+		if code == ReturnOkMulti || code == ReturnOkData {
+			v := []string{msg}
 
-		for {
-			b, err := r.ReadSlice('\n')
-			if err != nil {
-				return 0, "", err
-			}
+			for {
+				b, err := r.ReadSlice('\n')
+				if err != nil {
+					c.closeAll(err)
+					return
+				}
 
-			endsWithCRNL := len(b) > 0 && bytes.Compare(b[len(b)-2:], crnl) == 0
-			if endsWithCRNL {
-				line = string(b[:len(b)-2])
-			} else if b[len(b)-1] == '\n' {
-				line = string(b[:len(b)-1])
-			}
-			v = append(v, line)
+				endsWithCRNL := len(b) > 0 && bytes.Compare(b[len(b)-2:], crnl) == 0
+				if endsWithCRNL {
+					line = string(b[:len(b)-2])
+				} else if b[len(b)-1] == '\n' {
+					line = string(b[:len(b)-1])
+				}
+				if l := c.getLogger(); l != nil {
+					l.Log(DirRecv, redactForLog(line))
+				}
+				v = append(v, line)
 
-			// Single line data ends with CRNL on the end
-			if code == ReturnOkData && endsWithCRNL {
-				break
-			}
+				// Single line data ends with CRNL on the end
+				if code == ReturnOkData && endsWithCRNL {
+					break
+				}
 
-			// Multi line data ends has an extra line with only CRNL to signal end
-			if code == ReturnOkMulti && len(b) == 2 && endsWithCRNL {
-				break
+				// Multi line data ends has an extra line with only CRNL to signal end
+				if code == ReturnOkMulti && len(b) == 2 && endsWithCRNL {
+					break
+				}
 			}
+			data = v
+		}
 
+		c.deliver(response{code: code, data: data})
+	}
+}
+
+// hasPending reports whether any Do/DoContext call is currently waiting
+// on a reply.
+func (c *Client) hasPending() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending) > 0
+}
+
+// deliver hands a parsed reply to the oldest pending call, in the same
+// order its request was written to the connection.
+func (c *Client) deliver(resp response) {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	ch := c.pending[0]
+	c.pending = c.pending[1:]
+	c.mu.Unlock()
+	ch <- resp
+}
+
+// dispatchEvent fans out a line that wasn't a recognized reply to every
+// subscribed channel, dropping it for subscribers that aren't keeping up.
+func (c *Client) dispatchEvent(line string) {
+	cmd, args, perr := ParseCommand(line)
+	ev := Event{Raw: line}
+	if perr == nil {
+		ev.Cmd = cmd
+		ev.Args = args
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for s := range c.subs {
+		select {
+		case s.ch <- ev:
+		default:
 		}
-		data = v
 	}
+}
 
-	return code, data, nil
+// closeAll fails every pending call and closes every subscription after
+// the connection is lost.
+func (c *Client) closeAll(err error) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	subs := c.subs
+	c.subs = nil
+	c.mu.Unlock()
+
+	for _, ch := range pending {
+		ch <- response{err: err}
+	}
+	for s := range subs {
+		close(s.ch)
+	}
 }
 
 // parse a line from the server including its return code and rest of the data.
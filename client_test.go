@@ -0,0 +1,127 @@
+package amcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientSubscribe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if _, err := r.ReadString('\n'); err != nil {
+			return
+		}
+		conn.Write([]byte("202 OK\r\n"))
+		conn.Write([]byte("ADD 1 LOG\r\n"))
+		// Keep the connection open for the rest of the test.
+		time.Sleep(500 * time.Millisecond)
+	}()
+
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, err := c.DoContext(context.Background(), "VERSION")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != ReturnOk {
+		t.Fatalf("got code %d, want %d", code, ReturnOk)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Cmd != "ADD" {
+			t.Fatalf("got event %+v, want Cmd ADD", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestClientMalformedReplyFailsPendingCall(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c, err := newClient(a, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	go func() {
+		bufio.NewReader(b).ReadString('\n')
+		b.Write([]byte("GARBAGE NOT A REPLY\r\n"))
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.Do("VERSION")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error for a malformed reply, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Do blocked forever instead of failing on a malformed reply")
+	}
+}
+
+func TestClientDoContextDeadlineExceeded(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(500 * time.Millisecond)
+	}()
+
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err = c.DoContext(ctx, "VERSION")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got err %v, want context.DeadlineExceeded", err)
+	}
+}
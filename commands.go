@@ -0,0 +1,318 @@
+package amcp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// layerAddr formats a channel and layer as the "channel" or
+// "channel-layer" address AMCP commands expect. A zero layer addresses
+// the channel itself.
+func layerAddr(channel, layer int) string {
+	if layer == 0 {
+		return strconv.Itoa(channel)
+	}
+	return fmt.Sprintf("%d-%d", channel, layer)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// A CommandError reports that an AMCP server rejected a command with a
+// non-success return code, such as 401 ILLEGAL_VIDEO_CHANNEL or 404
+// MEDIA_NOT_FOUND. Unlike a transport error, it means the round trip
+// succeeded and the server understood the request.
+type CommandError struct {
+	Code    int
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("amcp: %d %s", e.Code, e.Message)
+}
+
+// checkReply turns a non-2xx AMCP return code into a *CommandError. The
+// typed command methods use this so a rejected command surfaces as an
+// error instead of a quietly ignored return code.
+func checkReply(code int, data interface{}) error {
+	if code >= 200 && code < 300 {
+		return nil
+	}
+	msg, _ := data.(string)
+	return &CommandError{Code: code, Message: msg}
+}
+
+// PlayOptions holds the optional parameters of a PLAY command.
+type PlayOptions struct {
+	// Loop causes the clip to loop continuously.
+	Loop bool
+	// Seek starts playback this many frames into the clip.
+	Seek int
+	// Length limits playback to this many frames.
+	Length int
+}
+
+// Play starts playback of clip on the given channel and layer. If clip
+// is empty, PLAY resumes a previously loaded but stopped clip.
+func (c *Client) Play(channel, layer int, clip string, opts PlayOptions) error {
+	args := []interface{}{layerAddr(channel, layer)}
+	if clip != "" {
+		args = append(args, clip)
+	}
+	if opts.Loop {
+		args = append(args, "LOOP")
+	}
+	if opts.Seek > 0 {
+		args = append(args, "SEEK", opts.Seek)
+	}
+	if opts.Length > 0 {
+		args = append(args, "LENGTH", opts.Length)
+	}
+	code, data, err := c.Do("PLAY", args...)
+	if err != nil {
+		return err
+	}
+	return checkReply(code, data)
+}
+
+// Load loads clip onto the given channel and layer without starting
+// playback.
+func (c *Client) Load(channel, layer int, clip string) error {
+	code, data, err := c.Do("LOAD", layerAddr(channel, layer), clip)
+	if err != nil {
+		return err
+	}
+	return checkReply(code, data)
+}
+
+// Stop stops playback on the given channel and layer, leaving the last
+// frame visible.
+func (c *Client) Stop(channel, layer int) error {
+	code, data, err := c.Do("STOP", layerAddr(channel, layer))
+	if err != nil {
+		return err
+	}
+	return checkReply(code, data)
+}
+
+// Clear removes the given layer, or every layer on channel if layer is
+// zero.
+func (c *Client) Clear(channel, layer int) error {
+	var code int
+	var data interface{}
+	var err error
+	if layer == 0 {
+		code, data, err = c.Do("CLEAR", channel)
+	} else {
+		code, data, err = c.Do("CLEAR", layerAddr(channel, layer))
+	}
+	if err != nil {
+		return err
+	}
+	return checkReply(code, data)
+}
+
+// Mixer groups the MIXER subcommands for a single channel and layer.
+type Mixer struct {
+	c              *Client
+	channel, layer int
+}
+
+// Mixer returns a Mixer for issuing MIXER commands against the given
+// channel and layer.
+func (c *Client) Mixer(channel, layer int) *Mixer {
+	return &Mixer{c: c, channel: channel, layer: layer}
+}
+
+// Chroma applies, or disables, chroma keying on the layer. The
+// parameters match the CasparCG MIXER CHROMA arguments in order.
+func (m *Mixer) Chroma(enable bool, targetHue, hueWidth, minSaturation, minBrightness, softness, spillSuppress, spillSuppressSaturation, chromaShow float64) error {
+	code, data, err := m.c.Do("MIXER", layerAddr(m.channel, m.layer), "CHROMA",
+		boolInt(enable), targetHue, hueWidth, minSaturation, minBrightness,
+		softness, spillSuppress, spillSuppressSaturation, chromaShow)
+	if err != nil {
+		return err
+	}
+	return checkReply(code, data)
+}
+
+// A Channel describes one of the server's configured video channels, as
+// reported by Info.Channels.
+type Channel struct {
+	Index  int
+	Format string
+	// Status is the channel's state, e.g. PLAYING.
+	Status string
+}
+
+// Info groups commands that query server state.
+type Info struct {
+	c *Client
+}
+
+// Info returns an Info for querying the server's current state.
+func (c *Client) Info() *Info {
+	return &Info{c: c}
+}
+
+// Channels returns the server's configured video channels.
+func (i *Info) Channels() ([]Channel, error) {
+	code, data, err := i.c.Do("INFO")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReply(code, data); err != nil {
+		return nil, err
+	}
+	lines, _ := data.([]string)
+	channels := make([]Channel, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		ch, err := parseChannelLine(line)
+		if err != nil {
+			continue
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
+
+func parseChannelLine(line string) (Channel, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return Channel{}, fmt.Errorf("amcp: malformed channel line: %q", line)
+	}
+	idx, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Channel{}, fmt.Errorf("amcp: malformed channel line: %q", line)
+	}
+	ch := Channel{Index: idx, Format: fields[1]}
+	if len(fields) > 2 {
+		ch.Status = fields[2]
+	}
+	return ch, nil
+}
+
+// A MediaItem describes a single media file, as reported by Cls.
+type MediaItem struct {
+	Name      string
+	Type      string
+	Size      int64
+	Frames    int64
+	FrameRate string
+}
+
+// Cls lists the media files available to the server.
+func (c *Client) Cls() ([]MediaItem, error) {
+	code, data, err := c.Do("CLS")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReply(code, data); err != nil {
+		return nil, err
+	}
+	lines, _ := data.([]string)
+	items := make([]MediaItem, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		item, err := parseMediaLine(line)
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseMediaLine parses a single CLS response line, e.g.
+// `"AMB" MOVIE 20199578 20150112221236 1250 25/1`. It reuses
+// ParseCommand's tokenizer since the name is quoted the same way a
+// command argument would be.
+func parseMediaLine(line string) (MediaItem, error) {
+	name, args, err := ParseCommand(line)
+	if err != nil {
+		return MediaItem{}, err
+	}
+	if len(args) < 2 {
+		return MediaItem{}, fmt.Errorf("amcp: malformed media line: %q", line)
+	}
+	item := MediaItem{Name: name, Type: args[0]}
+	if size, err := strconv.ParseInt(args[1], 10, 64); err == nil {
+		item.Size = size
+	}
+	if len(args) > 3 {
+		if frames, err := strconv.ParseInt(args[3], 10, 64); err == nil {
+			item.Frames = frames
+		}
+	}
+	if len(args) > 4 {
+		item.FrameRate = args[4]
+	}
+	return item, nil
+}
+
+// Tls lists the names of the template graphics available to the server.
+func (c *Client) Tls() ([]string, error) {
+	code, data, err := c.Do("TLS")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReply(code, data); err != nil {
+		return nil, err
+	}
+	lines, _ := data.([]string)
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		name, _, err := ParseCommand(line)
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Thumbnail groups the THUMBNAIL subcommands.
+type Thumbnail struct {
+	c *Client
+}
+
+// Thumbnail returns a Thumbnail for retrieving rendered clip previews.
+func (c *Client) Thumbnail() *Thumbnail {
+	return &Thumbnail{c: c}
+}
+
+// Retrieve returns the decoded thumbnail image data for name.
+func (t *Thumbnail) Retrieve(name string) ([]byte, error) {
+	code, data, err := t.c.Do("THUMBNAIL", "RETRIEVE", name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReply(code, data); err != nil {
+		return nil, err
+	}
+	// A 201 reply carries the image on the line after the status line.
+	var s string
+	switch v := data.(type) {
+	case string:
+		s = v
+	case []string:
+		if len(v) > 1 {
+			s = v[1]
+		}
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
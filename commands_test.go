@@ -0,0 +1,212 @@
+package amcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// newHandlerServer starts a local Server with the given handlers and
+// returns a Client dialed to it, for exercising the typed command layer
+// end to end.
+func newHandlerServer(t *testing.T, handlers map[string]HandlerFunc) (c *Client, shutdown func()) {
+	t.Helper()
+	srv := NewServer()
+	for cmd, fn := range handlers {
+		srv.Handle(cmd, fn)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+
+	c, err = Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, func() {
+		c.Close()
+		srv.Shutdown(context.Background())
+	}
+}
+
+func TestSimpleCommandsReturnCommandError(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		call func(c *Client) error
+	}{
+		{"Play", "PLAY", func(c *Client) error { return c.Play(99, 1, "missing.mp4", PlayOptions{}) }},
+		{"Load", "LOAD", func(c *Client) error { return c.Load(99, 1, "missing.mp4") }},
+		{"Stop", "STOP", func(c *Client) error { return c.Stop(99, 1) }},
+		{"Clear", "CLEAR", func(c *Client) error { return c.Clear(99, 1) }},
+		{"MixerChroma", "MIXER", func(c *Client) error {
+			return c.Mixer(99, 1).Chroma(true, 0, 0, 0, 0, 0, 0, 0, 0)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+				tt.cmd: func(ctx *Context, args []string) (int, interface{}, error) {
+					return ReturnIllegalVideoChannel, "ILLEGAL_VIDEO_CHANNEL", nil
+				},
+			})
+			defer shutdown()
+
+			err := tt.call(c)
+			var cerr *CommandError
+			if !errors.As(err, &cerr) || cerr.Code != ReturnIllegalVideoChannel {
+				t.Fatalf("got %v, want *CommandError with code %d", err, ReturnIllegalVideoChannel)
+			}
+		})
+	}
+}
+
+func TestSimpleCommandsSucceed(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		call func(c *Client) error
+	}{
+		{"Play", "PLAY", func(c *Client) error { return c.Play(1, 1, "clip.mp4", PlayOptions{}) }},
+		{"Load", "LOAD", func(c *Client) error { return c.Load(1, 1, "clip.mp4") }},
+		{"Stop", "STOP", func(c *Client) error { return c.Stop(1, 1) }},
+		{"Clear", "CLEAR", func(c *Client) error { return c.Clear(1, 1) }},
+		{"MixerChroma", "MIXER", func(c *Client) error {
+			return c.Mixer(1, 1).Chroma(true, 120, 0.1, 0, 0, 0.1, 0.1, 0.7, 0)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+				tt.cmd: func(ctx *Context, args []string) (int, interface{}, error) {
+					return ReturnOk, nil, nil
+				},
+			})
+			defer shutdown()
+
+			if err := tt.call(c); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInfoChannelsCommandError(t *testing.T) {
+	c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+		"INFO": func(ctx *Context, args []string) (int, interface{}, error) {
+			return ReturnServerError, "SERVER_ERROR", nil
+		},
+	})
+	defer shutdown()
+
+	if _, err := c.Info().Channels(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestInfoChannelsSucceeds(t *testing.T) {
+	c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+		"INFO": func(ctx *Context, args []string) (int, interface{}, error) {
+			return ReturnOkMulti, []string{"1 720p5000 PLAYING", ""}, nil
+		},
+	})
+	defer shutdown()
+
+	channels, err := c.Info().Channels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Channel{{Index: 1, Format: "720p5000", Status: "PLAYING"}}
+	if len(channels) != 1 || channels[0] != want[0] {
+		t.Fatalf("got %+v, want %+v", channels, want)
+	}
+}
+
+func TestClsCommandError(t *testing.T) {
+	c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+		"CLS": func(ctx *Context, args []string) (int, interface{}, error) {
+			return ReturnAccessError, "ACCESS_ERROR", nil
+		},
+	})
+	defer shutdown()
+
+	if _, err := c.Cls(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestTlsCommandError(t *testing.T) {
+	c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+		"TLS": func(ctx *Context, args []string) (int, interface{}, error) {
+			return ReturnAccessError, "ACCESS_ERROR", nil
+		},
+	})
+	defer shutdown()
+
+	if _, err := c.Tls(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestThumbnailRetrieveCommandError(t *testing.T) {
+	c, shutdown := newHandlerServer(t, map[string]HandlerFunc{
+		"THUMBNAIL": func(ctx *Context, args []string) (int, interface{}, error) {
+			return ReturnMediaNotFound, "MEDIA_NOT_FOUND", nil
+		},
+	})
+	defer shutdown()
+
+	if _, err := c.Thumbnail().Retrieve("missing"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLayerAddr(t *testing.T) {
+	tests := []struct {
+		channel, layer int
+		want           string
+	}{
+		{1, 0, "1"},
+		{1, 1, "1-1"},
+		{2, 10, "2-10"},
+	}
+	for _, tt := range tests {
+		if got := layerAddr(tt.channel, tt.layer); got != tt.want {
+			t.Errorf("layerAddr(%d, %d) = %q, want %q", tt.channel, tt.layer, got, tt.want)
+		}
+	}
+}
+
+func TestParseChannelLine(t *testing.T) {
+	ch, err := parseChannelLine("1 720p5000 PLAYING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Channel{Index: 1, Format: "720p5000", Status: "PLAYING"}
+	if ch != want {
+		t.Errorf("got %+v, want %+v", ch, want)
+	}
+
+	if _, err := parseChannelLine("bogus"); err == nil {
+		t.Fatal("expected error for malformed channel line")
+	}
+}
+
+func TestParseMediaLine(t *testing.T) {
+	item, err := parseMediaLine(`"AMB" MOVIE 20199578 20150112221236 1250 25/1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MediaItem{Name: "AMB", Type: "MOVIE", Size: 20199578, Frames: 1250, FrameRate: "25/1"}
+	if item != want {
+		t.Errorf("got %+v, want %+v", item, want)
+	}
+
+	if _, err := parseMediaLine(`"AMB"`); err == nil {
+		t.Fatal("expected error for malformed media line")
+	}
+}
@@ -0,0 +1,118 @@
+package amcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Directional tags passed to Logger.Log for each frame, matching the
+// "->"/"<-" convention commonly used for protocol tracing.
+const (
+	DirSent = "->"
+	DirRecv = "<-"
+)
+
+// maxLogPayload is the longest DATA payload or received line logged in
+// full. A longer DATA payload is replaced by its byte count, and a longer
+// received line is cut to this many bytes followed by its total length.
+// Other command arguments are logged in full whatever their length.
+const maxLogPayload = 256
+
+// A Logger receives every frame sent to, and received from, an AMCP
+// server. dir is DirSent or DirRecv and line is a human-readable
+// rendering of the frame: a long DATA STORE value is replaced by its
+// byte count, a long received line (such as a THUMBNAIL RETRIEVE
+// response) is truncated, and binary data is quoted.
+//
+// Log is called from both the goroutine issuing a command and the
+// Client's background reader goroutine, so implementations must be safe
+// for concurrent use.
+type Logger interface {
+	Log(dir, line string)
+}
+
+// SetLogger installs l as the Client's protocol logger. Passing nil
+// disables logging.
+func (c *Client) SetLogger(l Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+}
+
+func (c *Client) getLogger() Logger {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.logger
+}
+
+// renderForLog builds a log-friendly rendering of an outgoing command.
+// The last argument of a DATA command is replaced by its byte count when
+// it is longer than maxLogPayload; every other string argument is logged
+// in full, quoted if it holds whitespace or binary data.
+func renderForLog(cmd string, args []interface{}) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, cmd)
+
+	isDataStore := strings.EqualFold(cmd, "DATA") && len(args) > 0
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			parts = append(parts, fmt.Sprint(arg))
+			continue
+		}
+		if isDataStore && i == len(args)-1 && len(s) > maxLogPayload {
+			parts = append(parts, fmt.Sprintf("<%d bytes redacted>", len(s)))
+			continue
+		}
+		parts = append(parts, quoteForLog(s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactForLog prepares a raw line read from the connection for logging,
+// truncating overly long lines (e.g. a THUMBNAIL RETRIEVE payload) and
+// quoting any that contain binary data.
+func redactForLog(line string) string {
+	if len(line) > maxLogPayload {
+		return fmt.Sprintf("%s... (%d bytes)", quoteForLog(line[:maxLogPayload]), len(line))
+	}
+	return quoteForLog(line)
+}
+
+func quoteForLog(s string) string {
+	if isBinary(s) || strings.ContainsAny(s, " \t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func isBinary(s string) bool {
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+// A SlogLogger adapts a log/slog.Handler to the Logger interface, logging
+// each frame as a debug record with "dir" and "line" attributes.
+type SlogLogger struct {
+	Handler slog.Handler
+}
+
+// NewSlogLogger returns a Logger that writes each frame to h.
+func NewSlogLogger(h slog.Handler) *SlogLogger {
+	return &SlogLogger{Handler: h}
+}
+
+// Log implements Logger.
+func (s *SlogLogger) Log(dir, line string) {
+	r := slog.NewRecord(time.Now(), slog.LevelDebug, "amcp", 0)
+	r.AddAttrs(slog.String("dir", dir), slog.String("line", line))
+	_ = s.Handler.Handle(context.Background(), r)
+}
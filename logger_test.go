@@ -0,0 +1,85 @@
+package amcp
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (m *memLogger) Log(dir, line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lines = append(m.lines, dir+" "+line)
+}
+
+func TestRenderForLogRedactsDataStore(t *testing.T) {
+	payload := strings.Repeat("x", maxLogPayload+1)
+	got := renderForLog("DATA", []interface{}{"STORE", "key", payload})
+	if strings.Contains(got, payload) {
+		t.Fatalf("expected payload to be redacted, got %q", got)
+	}
+	if !strings.Contains(got, "bytes redacted") {
+		t.Fatalf("expected redaction marker, got %q", got)
+	}
+}
+
+func TestRenderForLogPassesThroughShortArgs(t *testing.T) {
+	got := renderForLog("PLAY", []interface{}{"1-1", "MY_FILE", 10})
+	want := "PLAY 1-1 MY_FILE 10"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRedactForLogTruncatesLongLines(t *testing.T) {
+	line := "201 THUMBNAIL RETRIEVE OK\r\n" + strings.Repeat("A", maxLogPayload*2)
+	got := redactForLog(line)
+	if len(got) >= len(line) {
+		t.Fatalf("expected truncated line, got len %d", len(got))
+	}
+	if !strings.Contains(got, "bytes)") {
+		t.Fatalf("expected byte count suffix, got %q", got)
+	}
+}
+
+func TestClientLogsFrames(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c, err := newClient(a, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &memLogger{}
+	c.SetLogger(l)
+
+	go func() {
+		br := bufio.NewReader(b)
+		br.ReadString('\n')
+		b.Write([]byte("202 OK\r\n"))
+	}()
+
+	if _, _, err := c.Do("VERSION"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) != 2 {
+		t.Fatalf("got %d logged lines, want 2: %v", len(l.lines), l.lines)
+	}
+	if !strings.HasPrefix(l.lines[0], DirSent) {
+		t.Errorf("first line not sent: %q", l.lines[0])
+	}
+	if !strings.HasPrefix(l.lines[1], DirRecv) {
+		t.Errorf("second line not received: %q", l.lines[1])
+	}
+}
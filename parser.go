@@ -0,0 +1,96 @@
+package amcp
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+// ParseCommand parses a single AMCP command line, splitting it into its
+// command name and arguments. It is the inverse of formatCmd for the
+// arguments formatCmd quotes: quoted fields are unquoted, and the escape
+// sequences \", \\, and \n inside them are unescaped. Unquoted fields are
+// split on whitespace and returned as is, without unescaping, so a string
+// that formatCmd escapes but does not quote (one holding a backslash or a
+// double quote and no whitespace) does not round-trip.
+//
+// ParseCommand returns a net/textproto.ProtocolError if line is
+// malformed, such as an unterminated quoted string or a dangling
+// backslash escape.
+func ParseCommand(line string) (cmd string, args []string, err error) {
+	fields, err := splitArgs(line)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(fields) == 0 {
+		return "", nil, textproto.ProtocolError("amcp: empty command")
+	}
+	return fields[0], fields[1:], nil
+}
+
+// splitArgs tokenizes line into whitespace-separated fields, unquoting
+// and unescaping any double-quoted field as produced by formatCmd.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	n := len(line)
+	i := 0
+	for i < n {
+		for i < n && isCmdSpace(line[i]) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+
+		if line[i] != '"' {
+			start := i
+			for i < n && !isCmdSpace(line[i]) {
+				i++
+			}
+			args = append(args, line[start:i])
+			continue
+		}
+
+		start := i
+		i++
+		var b strings.Builder
+		closed := false
+		for i < n {
+			switch c := line[i]; c {
+			case '"':
+				closed = true
+				i++
+			case '\\':
+				if i+1 >= n {
+					return nil, textproto.ProtocolError(fmt.Sprintf("amcp: dangling escape at %d", i))
+				}
+				switch line[i+1] {
+				case '"':
+					b.WriteByte('"')
+				case '\\':
+					b.WriteByte('\\')
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					return nil, textproto.ProtocolError(fmt.Sprintf("amcp: invalid escape sequence at %d", i))
+				}
+				i += 2
+				continue
+			default:
+				b.WriteByte(c)
+				i++
+				continue
+			}
+			break
+		}
+		if !closed {
+			return nil, textproto.ProtocolError(fmt.Sprintf("amcp: unterminated quoted string starting at %d", start))
+		}
+		args = append(args, b.String())
+	}
+	return args, nil
+}
+
+func isCmdSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
@@ -0,0 +1,48 @@
+package amcp
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	for i, exp := range formatExp {
+		line := exp[:len(exp)-2] // trim trailing CRNL
+
+		cmd, args, err := ParseCommand(line)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if want := formatTests[i].cmd; cmd != want {
+			t.Errorf("case %d: got cmd %q, want %q", i, cmd, want)
+		}
+
+		// Re-formatting the parsed (string) arguments should reproduce
+		// the original line exactly, proving ParseCommand inverts
+		// formatCmd.
+		if got := formatCmd(cmd, toArgs(args)...); got != exp {
+			t.Errorf("case %d: round trip mismatch: got %q, want %q", i, got, exp)
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	tests := []string{
+		"",
+		`DATA STORE key "unterminated`,
+		`DATA STORE key "trailing backslash\`,
+		`DATA STORE key "bad \q escape"`,
+	}
+	for _, line := range tests {
+		if _, _, err := ParseCommand(line); err == nil {
+			t.Errorf("ParseCommand(%q): expected error, got nil", line)
+		}
+	}
+}
+
+func toArgs(ss []string) []interface{} {
+	args := make([]interface{}, len(ss))
+	for i, s := range ss {
+		args[i] = s
+	}
+	return args
+}
@@ -0,0 +1,219 @@
+package amcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults used by NewPool.
+const (
+	DefaultMaxConns    = 8
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// healthCheckTimeout bounds the VERSION round trip get() uses to verify
+// an idle connection is still alive. Without a deadline, a half-dead
+// connection (peer gone but no FIN/RST yet) could block the calling
+// goroutine forever instead of being evicted.
+const healthCheckTimeout = 2 * time.Second
+
+// A Pool manages a bounded set of Client connections to a single AMCP
+// endpoint. Do checks out an idle connection (dialing a new one if the
+// pool has not reached Max), sends the command, and returns the
+// connection to the pool for reuse. This lets broadcast workflows that
+// issue many commands at once, e.g. several CG ADD/MIXER calls across
+// channels, run them concurrently instead of serializing through a
+// single Client.
+type Pool struct {
+	// Min is the number of idle connections Warm makes sure the pool
+	// holds. The pool does not redial to stay at Min once connections
+	// are later evicted or closed for being idle.
+	Min int
+	// Max caps the number of open connections. Zero means unbounded.
+	Max int
+	// IdleTimeout closes an idle connection that has not been used for
+	// longer than this. Zero disables idle eviction.
+	IdleTimeout time.Duration
+
+	addr string
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	idle    []*pooledConn
+	numOpen int
+	closed  bool
+}
+
+type pooledConn struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+// NewPool returns a new Pool that dials addr on demand, up to
+// DefaultMaxConns connections, evicting idle connections after
+// DefaultIdleTimeout.
+func NewPool(addr string) *Pool {
+	p := &Pool{
+		addr:        addr,
+		Max:         DefaultMaxConns,
+		IdleTimeout: DefaultIdleTimeout,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Warm eagerly opens connections until the pool holds at least Min idle
+// connections. It returns an error if Min exceeds Max, since the pool
+// could never hold that many connections at once; dialing directly,
+// rather than through get(), keeps Warm from blocking on p.cond.Wait
+// forever in that case instead of making progress.
+func (p *Pool) Warm() error {
+	if p.Max > 0 && p.Min > p.Max {
+		return fmt.Errorf("amcp: pool Min (%d) exceeds Max (%d)", p.Min, p.Max)
+	}
+
+	p.mu.Lock()
+	need := p.Min - len(p.idle)
+	p.mu.Unlock()
+
+	conns := make([]*Client, 0, p.Min)
+	for i := 0; i < need; i++ {
+		c, err := Dial(p.addr)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return err
+		}
+		conns = append(conns, c)
+	}
+
+	p.mu.Lock()
+	p.numOpen += len(conns)
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		p.put(c)
+	}
+	return nil
+}
+
+// Do checks out an idle connection, sends cmd, and returns the
+// connection to the pool. If the command fails with a network error,
+// the connection is closed and evicted from the pool instead of being
+// reused.
+func (p *Pool) Do(cmd string, args ...interface{}) (code int, data interface{}, err error) {
+	c, err := p.get()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	code, data, err = c.Do(cmd, args...)
+	if err != nil && isNetError(err) {
+		p.evict(c)
+		return code, data, err
+	}
+	p.put(c)
+	return code, data, err
+}
+
+// Close closes every idle connection and prevents the pool from handing
+// out any more. Connections currently checked out by a Do call are
+// closed as they are returned.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	p.cond.Broadcast()
+
+	var err error
+	for _, pc := range idle {
+		if cerr := pc.client.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// get returns an idle connection, health-checked with VERSION, dialing a
+// new one if the pool is under Max. It blocks if the pool is already at
+// Max and has no idle connections, until one is returned or evicted.
+func (p *Pool) get() (*Client, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errors.New("amcp: pool closed")
+		}
+
+		if len(p.idle) > 0 {
+			pc := p.idle[len(p.idle)-1]
+			p.idle = p.idle[:len(p.idle)-1]
+			expired := p.IdleTimeout > 0 && time.Since(pc.lastUsed) > p.IdleTimeout
+			p.mu.Unlock()
+
+			if expired {
+				p.closeAndForget(pc.client)
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			_, _, err := pc.client.DoContext(ctx, "VERSION")
+			cancel()
+			if err != nil {
+				p.closeAndForget(pc.client)
+				continue
+			}
+			return pc.client, nil
+		}
+
+		if p.Max <= 0 || p.numOpen < p.Max {
+			p.numOpen++
+			p.mu.Unlock()
+
+			c, err := Dial(p.addr)
+			if err != nil {
+				p.mu.Lock()
+				p.numOpen--
+				p.mu.Unlock()
+				p.cond.Broadcast()
+				return nil, err
+			}
+			return c, nil
+		}
+
+		p.cond.Wait()
+		p.mu.Unlock()
+	}
+}
+
+// put returns c to the pool for reuse, or closes it if the pool has
+// since been closed.
+func (p *Pool) put(c *Client) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, &pooledConn{client: c, lastUsed: time.Now()})
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// evict closes c and removes it from the pool's open connection count.
+func (p *Pool) evict(c *Client) {
+	p.closeAndForget(c)
+}
+
+func (p *Pool) closeAndForget(c *Client) {
+	c.Close()
+	p.mu.Lock()
+	p.numOpen--
+	p.mu.Unlock()
+	p.cond.Signal()
+}
@@ -0,0 +1,166 @@
+package amcp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (addr string, shutdown func()) {
+	t.Helper()
+	srv := NewServer()
+	srv.Handle("VERSION", func(ctx *Context, args []string) (int, interface{}, error) {
+		return ReturnOk, "2.3.2", nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	return l.Addr().String(), func() { srv.Shutdown(context.Background()) }
+}
+
+func TestPoolDo(t *testing.T) {
+	addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	p := NewPool(addr)
+	p.Max = 2
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, data, err := p.Do("VERSION")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if code != ReturnOk || data != "2.3.2" {
+				t.Errorf("got (%d, %v), want (%d, %q)", code, data, ReturnOk, "2.3.2")
+			}
+		}()
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	open := p.numOpen
+	p.mu.Unlock()
+	if open > p.Max {
+		t.Errorf("numOpen = %d, exceeds Max = %d", open, p.Max)
+	}
+}
+
+func TestPoolWarm(t *testing.T) {
+	addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	p := NewPool(addr)
+	p.Min = 3
+	p.Max = 3
+	defer p.Close()
+
+	if err := p.Warm(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	idle := len(p.idle)
+	p.mu.Unlock()
+	if idle != 3 {
+		t.Errorf("got %d idle connections, want 3", idle)
+	}
+}
+
+func TestPoolWarmMinExceedsMaxReturnsError(t *testing.T) {
+	addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	p := NewPool(addr)
+	p.Min = 5
+	p.Max = 2
+	defer p.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.Warm() }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error when Min exceeds Max, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Warm blocked instead of returning an error when Min exceeds Max")
+	}
+}
+
+func TestPoolGetEvictsUnresponsiveIdleConn(t *testing.T) {
+	addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	// A listener that accepts a connection and then never replies,
+	// simulating a peer that's gone but hasn't sent a FIN/RST yet.
+	stuck, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stuck.Close()
+	go func() {
+		conn, err := stuck.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		select {} // never read or respond
+	}()
+
+	stuckClient, err := Dial(stuck.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPool(addr)
+	p.Max = 1
+	defer p.Close()
+
+	// Seed the pool with the half-dead connection as if it had been
+	// checked out and returned normally.
+	p.mu.Lock()
+	p.numOpen = 1
+	p.idle = append(p.idle, &pooledConn{client: stuckClient, lastUsed: time.Now()})
+	p.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := p.Do("VERSION")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected Do to evict the unresponsive conn and redial, got: %v", err)
+		}
+	case <-time.After(healthCheckTimeout + 2*time.Second):
+		t.Fatal("Pool.Do blocked well past healthCheckTimeout instead of evicting the unresponsive connection")
+	}
+}
+
+func TestPoolClosed(t *testing.T) {
+	addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	p := NewPool(addr)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := p.Do("VERSION"); err == nil {
+		t.Fatal("expected error from Do on closed pool")
+	}
+}
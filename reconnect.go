@@ -0,0 +1,214 @@
+package amcp
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff configures the delay between redial attempts of a
+// ReconnectingClient. The delay grows exponentially from BaseDelay by
+// Factor on each attempt, capped at MaxDelay, with up to Jitter applied
+// as a uniform random adjustment in both directions. This mirrors the
+// connection backoff policy used by gRPC.
+type Backoff struct {
+	// BaseDelay is the delay before the first redial attempt.
+	BaseDelay time.Duration
+	// Factor is the multiplier applied to the delay after each failed
+	// attempt.
+	Factor float64
+	// Jitter is the fraction of the computed delay to randomly add or
+	// subtract, e.g. 0.2 for +/-20%.
+	Jitter float64
+	// MaxDelay caps the computed delay.
+	MaxDelay time.Duration
+}
+
+// DefaultBackoff is the Backoff used by a ReconnectingClient when none is
+// configured.
+var DefaultBackoff = Backoff{
+	BaseDelay: time.Second,
+	Factor:    1.6,
+	Jitter:    0.2,
+	MaxDelay:  120 * time.Second,
+}
+
+// delay returns the backoff delay for the given retry count, where retry
+// 1 is the first redial attempt.
+func (b Backoff) delay(retry int) time.Duration {
+	if retry <= 0 {
+		return b.BaseDelay
+	}
+	d := float64(b.BaseDelay)
+	max := float64(b.MaxDelay)
+	for i := 0; i < retry-1 && d < max; i++ {
+		d *= b.Factor
+	}
+	if d > max {
+		d = max
+	}
+	d += d * b.Jitter * (rand.Float64()*2 - 1)
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// idempotentCmds holds the AMCP commands that are safe to resend against
+// a freshly redialed connection after a network failure, because they
+// have no side effects.
+var idempotentCmds = map[string]bool{
+	"INFO":    true,
+	"CLS":     true,
+	"VERSION": true,
+	"TLS":     true,
+}
+
+// Idempotent reports whether cmd may be safely retried against a new
+// connection after a network error.
+func Idempotent(cmd string) bool {
+	return idempotentCmds[strings.ToUpper(cmd)]
+}
+
+// A ReconnectingClient wraps a Client to an AMCP server, transparently
+// redialing with an exponential backoff (see Backoff) whenever Do fails
+// with a network error. This keeps long-lived sessions to a CasparCG
+// server alive across transient network interruptions.
+//
+// Only commands reported as idempotent by Idempotent are re-issued after
+// a redial; for any other command, the network error is returned to the
+// caller so it can decide whether to retry.
+type ReconnectingClient struct {
+	// Backoff controls the delay between redial attempts. The zero value
+	// is replaced with DefaultBackoff.
+	Backoff Backoff
+
+	addr string
+
+	mu      sync.Mutex
+	client  *Client
+	retries int
+}
+
+// NewReconnectingClient returns a ReconnectingClient that dials addr on
+// demand, using DefaultBackoff between redial attempts.
+func NewReconnectingClient(addr string) *ReconnectingClient {
+	return &ReconnectingClient{addr: addr, Backoff: DefaultBackoff}
+}
+
+// Close closes the underlying connection, if any.
+func (rc *ReconnectingClient) Close() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.client == nil {
+		return nil
+	}
+	err := rc.client.Close()
+	rc.client = nil
+	return err
+}
+
+// Do sends a command to the server, redialing and retrying as described
+// on ReconnectingClient if it fails with a network error.
+func (rc *ReconnectingClient) Do(cmd string, args ...interface{}) (code int, data interface{}, err error) {
+	return rc.DoContext(context.Background(), cmd, args...)
+}
+
+// DoContext is like Do but gives up redialing once ctx is done.
+func (rc *ReconnectingClient) DoContext(ctx context.Context, cmd string, args ...interface{}) (code int, data interface{}, err error) {
+	for {
+		c, err := rc.connect(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		code, data, err := c.Do(cmd, args...)
+		if err == nil {
+			rc.mu.Lock()
+			rc.retries = 0
+			rc.mu.Unlock()
+			return code, data, nil
+		}
+
+		if !isNetError(err) {
+			return code, data, err
+		}
+
+		// The connection is broken regardless of whether cmd is safe to
+		// resend, so evict it now; otherwise every subsequent call,
+		// including idempotent ones, would keep being handed the same
+		// dead connection and fail identically.
+		rc.mu.Lock()
+		if rc.client == c {
+			rc.client.Close()
+			rc.client = nil
+		}
+		rc.mu.Unlock()
+
+		if !Idempotent(cmd) {
+			return code, data, err
+		}
+
+		if werr := rc.wait(ctx); werr != nil {
+			return 0, nil, werr
+		}
+	}
+}
+
+// connect returns the current connection, dialing (and redialing on
+// failure, honoring Backoff) a new one if necessary.
+func (rc *ReconnectingClient) connect(ctx context.Context) (*Client, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.client != nil {
+		return rc.client, nil
+	}
+
+	for {
+		c, err := Dial(rc.addr)
+		if err == nil {
+			rc.client = c
+			rc.retries = 0
+			return c, nil
+		}
+
+		rc.retries++
+		d := rc.backoff().delay(rc.retries)
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (rc *ReconnectingClient) wait(ctx context.Context) error {
+	rc.mu.Lock()
+	rc.retries++
+	d := rc.backoff().delay(rc.retries)
+	rc.mu.Unlock()
+
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rc *ReconnectingClient) backoff() Backoff {
+	if rc.Backoff == (Backoff{}) {
+		return DefaultBackoff
+	}
+	return rc.Backoff
+}
+
+func isNetError(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne)
+}
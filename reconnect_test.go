@@ -0,0 +1,147 @@
+package amcp
+
+import (
+	"bufio"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// acceptAndHandle accepts connections on l, calling handle(n, conn) for
+// the nth (1-indexed) accepted connection.
+func acceptAndHandle(t *testing.T, l net.Listener, handle func(n int32, conn net.Conn)) {
+	t.Helper()
+	var accepts int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			n := atomic.AddInt32(&accepts, 1)
+			go handle(n, conn)
+		}
+	}()
+}
+
+// dropWithReset reads one command line from conn and then resets the
+// connection, simulating a network failure rather than a clean close (a
+// clean EOF doesn't implement net.Error and so wouldn't exercise
+// ReconnectingClient's eviction path).
+func dropWithReset(conn net.Conn) {
+	defer conn.Close()
+	bufio.NewReader(conn).ReadString('\n')
+	if tc, ok := conn.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+}
+
+func respondOK(conn net.Conn, reply string) {
+	defer conn.Close()
+	bufio.NewReader(conn).ReadString('\n')
+	conn.Write([]byte(reply))
+}
+
+func fastBackoff() Backoff {
+	return Backoff{BaseDelay: time.Millisecond, Factor: 1, Jitter: 0, MaxDelay: time.Millisecond}
+}
+
+func TestReconnectingClientRetriesIdempotentCommand(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	acceptAndHandle(t, l, func(n int32, conn net.Conn) {
+		if n == 1 {
+			dropWithReset(conn)
+			return
+		}
+		respondOK(conn, "202 2.3.2\r\n")
+	})
+
+	rc := NewReconnectingClient(l.Addr().String())
+	rc.Backoff = fastBackoff()
+	defer rc.Close()
+
+	code, data, err := rc.Do("VERSION")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != ReturnOk || data != "2.3.2" {
+		t.Fatalf("got (%d, %v), want (%d, %q)", code, data, ReturnOk, "2.3.2")
+	}
+}
+
+func TestReconnectingClientEvictsBrokenConnOnNonIdempotentFailure(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	acceptAndHandle(t, l, func(n int32, conn net.Conn) {
+		if n == 1 {
+			dropWithReset(conn)
+			return
+		}
+		respondOK(conn, "202 OK\r\n")
+	})
+
+	rc := NewReconnectingClient(l.Addr().String())
+	rc.Backoff = fastBackoff()
+	defer rc.Close()
+
+	// PLAY is not idempotent, so the first call should fail without an
+	// automatic retry...
+	if _, _, err := rc.Do("PLAY", "1-1", "clip.mp4"); err == nil {
+		t.Fatal("expected error from first PLAY against a reset connection")
+	}
+
+	// ...but the broken connection must have been evicted, so this call
+	// redials instead of reusing the dead socket.
+	code, _, err := rc.Do("PLAY", "1-1", "clip.mp4")
+	if err != nil {
+		t.Fatalf("second PLAY should have redialed and succeeded, got: %v", err)
+	}
+	if code != ReturnOk {
+		t.Fatalf("got code %d, want %d", code, ReturnOk)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{BaseDelay: 0, Factor: 2, Jitter: 0, MaxDelay: 0}
+	if got := b.delay(0); got != 0 {
+		t.Fatalf("retry 0: got %v, want 0", got)
+	}
+
+	b = DefaultBackoff
+	b.Jitter = 0
+	if got, want := b.delay(1), b.BaseDelay; got != want {
+		t.Fatalf("retry 1: got %v, want %v", got, want)
+	}
+	if got := b.delay(100); got != b.MaxDelay {
+		t.Fatalf("retry 100: got %v, want capped at %v", got, b.MaxDelay)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"INFO", true},
+		{"info", true},
+		{"VERSION", true},
+		{"CLS", true},
+		{"PLAY", false},
+		{"DATA", false},
+	}
+	for _, tt := range tests {
+		if got := Idempotent(tt.cmd); got != tt.want {
+			t.Errorf("Idempotent(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
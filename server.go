@@ -0,0 +1,240 @@
+package amcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A HandlerFunc handles a single AMCP command received by a Server. It
+// returns the AMCP return code for the command and any response data,
+// which may be nil, a string, or a []string for multi-line payloads.
+type HandlerFunc func(ctx *Context, args []string) (code int, data interface{}, err error)
+
+// A Context carries the state of a single command dispatched by a
+// Server, such as the connection it arrived on.
+type Context struct {
+	// Conn is the underlying connection the command was received on.
+	Conn net.Conn
+	// Cmd is the command name as sent by the client.
+	Cmd string
+}
+
+// A Server accepts AMCP connections and dispatches parsed commands to
+// handlers registered with Handle. The zero value is not usable; use
+// NewServer to construct one.
+type Server struct {
+	// ErrorLog, if non-nil, is used to log per-connection errors such as
+	// malformed command lines and handler failures.
+	ErrorLog *log.Logger
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	listener net.Listener
+	conns    map[*serverConn]struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer returns a new Server with no registered handlers.
+func NewServer() *Server {
+	return &Server{
+		handlers: make(map[string]HandlerFunc),
+		conns:    make(map[*serverConn]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Handle registers fn as the handler for cmd. Command names are matched
+// case-insensitively; registering a handler for a command that already
+// has one replaces it.
+func (s *Server) Handle(cmd string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[strings.ToUpper(cmd)] = fn
+}
+
+// ListenAndServe listens on addr and then calls Serve to handle incoming
+// connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l, parsing and dispatching commands on
+// each to their registered handler. Serve blocks until l is closed, either
+// by the caller or by Shutdown, in which case Serve returns nil.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		sc := &serverConn{srv: s, conn: conn, r: bufio.NewReader(conn)}
+		s.trackConn(sc, true)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.trackConn(sc, false)
+			sc.serve()
+		}()
+	}
+}
+
+func (s *Server) trackConn(sc *serverConn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		s.conns[sc] = struct{}{}
+	} else {
+		delete(s.conns, sc)
+	}
+}
+
+// Shutdown stops the server from accepting new connections and waits for
+// in-flight commands to finish before returning. If ctx is canceled
+// first, Shutdown forcibly closes any remaining connections and returns
+// ctx.Err().
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	// Stop every connection at its next read: an idle one ends now, and
+	// one in the middle of a command ends once it has written the reply.
+	for sc := range s.conns {
+		sc.conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for sc := range s.conns {
+			sc.conn.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	}
+}
+
+// serverConn handles the command loop for a single accepted connection.
+type serverConn struct {
+	srv  *Server
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (sc *serverConn) serve() {
+	defer sc.conn.Close()
+	for {
+		line, err := sc.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+
+		cmd, args, err := ParseCommand(line)
+		if err != nil {
+			sc.srv.logf("amcp: %s: %v", sc.conn.RemoteAddr(), err)
+			sc.writeResponse(ReturnClientError, nil)
+			continue
+		}
+
+		sc.srv.mu.Lock()
+		fn, ok := sc.srv.handlers[strings.ToUpper(cmd)]
+		sc.srv.mu.Unlock()
+		if !ok {
+			sc.writeResponse(ReturnClientError, nil)
+			continue
+		}
+
+		code, data, err := sc.dispatch(fn, cmd, args)
+		if err != nil {
+			sc.srv.logf("amcp: %s: %s: %v", sc.conn.RemoteAddr(), cmd, err)
+			if code == 0 {
+				code = ReturnServerErrorCommand
+			}
+		}
+		sc.writeResponse(code, data)
+	}
+}
+
+// dispatch runs fn, recovering a panic into an error so that a single
+// misbehaving handler can't take down the whole Server and every other
+// connection it's serving.
+func (sc *serverConn) dispatch(fn HandlerFunc, cmd string, args []string) (code int, data interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("amcp: handler panicked: %v", r)
+		}
+	}()
+	return fn(&Context{Conn: sc.conn, Cmd: cmd}, args)
+}
+
+// writeResponse writes a response line, and any multi-line payload, back
+// to the client in the same format produced by a real AMCP server.
+func (sc *serverConn) writeResponse(code int, data interface{}) {
+	var msg string
+	switch v := data.(type) {
+	case string:
+		msg = v
+	case []string:
+		if len(v) > 0 {
+			msg = v[0]
+		}
+	}
+
+	fmt.Fprintf(sc.conn, "%d %s\r\n", code, msg)
+
+	if lines, ok := data.([]string); ok {
+		if len(lines) > 0 {
+			for _, line := range lines[1:] {
+				fmt.Fprintf(sc.conn, "%s\r\n", line)
+			}
+		}
+		if code == ReturnOkMulti {
+			sc.conn.Write(crnl)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package amcp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestServerDispatch(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("version", func(ctx *Context, args []string) (int, interface{}, error) {
+		return ReturnOk, "2.3.2", nil
+	})
+	srv.Handle("CLS", func(ctx *Context, args []string) (int, interface{}, error) {
+		return ReturnOkMulti, []string{"", "\"AMB\" 0 1280 720 0.0"}, nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("VERSION\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), "202 2.3.2"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestServerDispatchEmptyMultiLineReply(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("CLS", func(ctx *Context, args []string) (int, interface{}, error) {
+		return ReturnOkMulti, []string{}, nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("CLS\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), "200 "; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	line, err = r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), ""; got != want {
+		t.Fatalf("got %q, want %q (terminating blank line)", got, want)
+	}
+}
+
+func TestServerDispatchRecoversPanickingHandler(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("BOOM", func(ctx *Context, args []string) (int, interface{}, error) {
+		panic("handler exploded")
+	})
+	srv.Handle("VERSION", func(ctx *Context, args []string) (int, interface{}, error) {
+		return ReturnOk, "2.3.2", nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Shutdown(context.Background())
+
+	boom, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer boom.Close()
+	if _, err := boom.Write([]byte("BOOM\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(boom)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), "501 "; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// The panic must not have taken the whole Server down: a second,
+	// unrelated connection should still be served normally.
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("VERSION\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r = bufio.NewReader(conn)
+	line, err = r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), "202 2.3.2"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestServerUnknownCommand(t *testing.T) {
+	srv := NewServer()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("BOGUS\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimRight(line, "\r\n"), "400 "; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
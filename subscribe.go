@@ -0,0 +1,57 @@
+package amcp
+
+import (
+	"context"
+	"errors"
+)
+
+// An Event is a single line pushed by the server outside of any Do
+// reply, such as an ADD/REMOVE notification. Cmd and Args are populated
+// by parsing Raw with ParseCommand; if that fails, both are empty and
+// Raw holds the line as received.
+type Event struct {
+	Cmd  string
+	Args []string
+	Raw  string
+}
+
+// sub is a single Subscribe registration. It exists separately from its
+// channel so the reader goroutine and an expiring context can agree on
+// whether it has already been closed.
+type sub struct {
+	ch chan Event
+}
+
+// Subscribe returns a channel of Events pushed by the server for as long
+// as ctx is not done. The channel is closed once ctx is canceled or the
+// connection is lost; callers should keep draining it promptly, since a
+// slow consumer causes events to be dropped rather than block the
+// connection's reader goroutine.
+func (c *Client) Subscribe(ctx context.Context) (<-chan Event, error) {
+	s := &sub{ch: make(chan Event, 16)}
+
+	c.mu.Lock()
+	if c.subs == nil {
+		c.mu.Unlock()
+		return nil, errors.New("amcp: connection closed")
+	}
+	c.subs[s] = struct{}{}
+	c.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.unsubscribe(s)
+	}()
+
+	return s.ch, nil
+}
+
+func (c *Client) unsubscribe(s *sub) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.subs[s]; !ok {
+		return
+	}
+	delete(c.subs, s)
+	close(s.ch)
+}